@@ -0,0 +1,106 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package certmaker
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ManifestEntry describes one certificate in a CreateCertificates run,
+// suitable for downstream tooling (e.g. a Sigstore TUF repository
+// generator) that needs to inspect the chain without re-parsing PEM files.
+type ManifestEntry struct {
+	Type              string    `json:"type"`
+	Path              string    `json:"path"`
+	NotBefore         time.Time `json:"notBefore"`
+	NotAfter          time.Time `json:"notAfter"`
+	SubjectKeyID      string    `json:"subjectKeyId,omitempty"`
+	AuthorityKeyID    string    `json:"authorityKeyId,omitempty"`
+	SHA256Fingerprint string    `json:"sha256Fingerprint"`
+	KeyAlgorithm      string    `json:"keyAlgorithm"`
+	KeyURI            string    `json:"keyUri,omitempty"`
+}
+
+// Manifest is the JSON document written to KMSConfig.ManifestOutputPath.
+type Manifest struct {
+	Certificates []ManifestEntry `json:"certificates"`
+}
+
+// newManifestEntry builds a ManifestEntry describing cert, as written to
+// path. keyURI is best-effort metadata (the canonical KMS URI the signing
+// key came from) and may be empty.
+func newManifestEntry(certType, path string, cert *x509.Certificate, keyURI string) ManifestEntry {
+	sum := sha256.Sum256(cert.Raw)
+	return ManifestEntry{
+		Type:              certType,
+		Path:              path,
+		NotBefore:         cert.NotBefore,
+		NotAfter:          cert.NotAfter,
+		SubjectKeyID:      hex.EncodeToString(cert.SubjectKeyId),
+		AuthorityKeyID:    hex.EncodeToString(cert.AuthorityKeyId),
+		SHA256Fingerprint: hex.EncodeToString(sum[:]),
+		KeyAlgorithm:      cert.PublicKeyAlgorithm.String(),
+		KeyURI:            keyURI,
+	}
+}
+
+// WriteManifestFile writes entries as a JSON Manifest to path.
+func WriteManifestFile(path string, entries []ManifestEntry) error {
+	manifest := Manifest{Certificates: entries}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling certificate manifest: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write manifest to file %s: %w", path, err)
+	}
+	fmt.Printf("Certificate manifest has been saved in %s.\n", path)
+	return nil
+}
+
+// WriteCertificateChainFile concatenates certs (in the order given, e.g.
+// leaf, intermediate, root) as PEM blocks into a single chain.pem-style
+// file at path.
+func WriteCertificateChainFile(path string, certs ...*x509.Certificate) error {
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificates provided for chain file %s", path)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+		if err := pem.Encode(file, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+			return fmt.Errorf("failed to write certificate to chain file %s: %w", path, err)
+		}
+	}
+
+	fmt.Printf("Certificate chain has been saved in %s.\n", path)
+	return nil
+}
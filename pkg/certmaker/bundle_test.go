@@ -0,0 +1,179 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package certmaker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestCert returns a self-signed certificate for use as test fixture
+// data; its contents aren't meaningful beyond being parseable and
+// distinguishable by CommonName.
+func newTestCert(t *testing.T, commonName string) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate test key: %v", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("failed to generate serial number: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		IsCA:         true,
+		SubjectKeyId: []byte(commonName),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create test certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse test certificate: %v", err)
+	}
+	return cert
+}
+
+func decodeAllPEM(t *testing.T, data []byte) []*pem.Block {
+	t.Helper()
+	var blocks []*pem.Block
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func TestWriteCertificateChainFile_Ordering(t *testing.T) {
+	dir := t.TempDir()
+	leaf := newTestCert(t, "leaf")
+	intermediate := newTestCert(t, "intermediate")
+	root := newTestCert(t, "root")
+
+	path := filepath.Join(dir, "chain.pem")
+	if err := WriteCertificateChainFile(path, leaf, intermediate, root); err != nil {
+		t.Fatalf("WriteCertificateChainFile returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read chain file: %v", err)
+	}
+	blocks := decodeAllPEM(t, data)
+	if len(blocks) != 3 {
+		t.Fatalf("got %d PEM blocks, want 3", len(blocks))
+	}
+
+	want := []*x509.Certificate{leaf, intermediate, root}
+	for i, block := range blocks {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("block %d: failed to parse certificate: %v", i, err)
+		}
+		if cert.Subject.CommonName != want[i].Subject.CommonName {
+			t.Errorf("block %d CommonName = %q, want %q (chain file is out of order)", i, cert.Subject.CommonName, want[i].Subject.CommonName)
+		}
+	}
+}
+
+func TestWriteCertificateChainFile_SkipsNilIntermediate(t *testing.T) {
+	dir := t.TempDir()
+	leaf := newTestCert(t, "leaf")
+	root := newTestCert(t, "root")
+
+	path := filepath.Join(dir, "bundle.pem")
+	if err := WriteCertificateChainFile(path, leaf, nil, root); err != nil {
+		t.Fatalf("WriteCertificateChainFile returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read chain file: %v", err)
+	}
+	if blocks := decodeAllPEM(t, data); len(blocks) != 2 {
+		t.Fatalf("got %d PEM blocks, want 2 (nil intermediate should be skipped)", len(blocks))
+	}
+}
+
+func TestWriteCertificateChainFile_NoCertificates(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteCertificateChainFile(filepath.Join(dir, "empty.pem")); err == nil {
+		t.Fatal("expected an error when no certificates are given")
+	}
+}
+
+func TestWriteManifestFile(t *testing.T) {
+	dir := t.TempDir()
+	root := newTestCert(t, "root")
+	leaf := newTestCert(t, "leaf")
+
+	entries := []ManifestEntry{
+		newManifestEntry("root", "root.pem", root, "awskms:///alias/root"),
+		newManifestEntry("leaf", "leaf.pem", leaf, ""),
+	}
+
+	path := filepath.Join(dir, "manifest.json")
+	if err := WriteManifestFile(path, entries); err != nil {
+		t.Fatalf("WriteManifestFile returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read manifest file: %v", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("failed to unmarshal manifest: %v", err)
+	}
+	if len(manifest.Certificates) != 2 {
+		t.Fatalf("got %d manifest entries, want 2", len(manifest.Certificates))
+	}
+	if manifest.Certificates[0].Type != "root" || manifest.Certificates[0].KeyURI != "awskms:///alias/root" {
+		t.Errorf("root entry = %+v, want Type=root KeyURI=awskms:///alias/root", manifest.Certificates[0])
+	}
+	if manifest.Certificates[0].SHA256Fingerprint == "" {
+		t.Error("root entry is missing a SHA256Fingerprint")
+	}
+	if manifest.Certificates[1].Type != "leaf" || manifest.Certificates[1].KeyURI != "" {
+		t.Errorf("leaf entry = %+v, want Type=leaf KeyURI=\"\"", manifest.Certificates[1])
+	}
+}
@@ -14,7 +14,7 @@
 //
 
 // Package certmaker implements a certificate creation utility for Fulcio.
-// It supports creating root, intermediate, and leaf certs using (AWS, GCP, Azure, HashiVault).
+// It supports creating root, intermediate, and leaf certs using (AWS, GCP, Azure, HashiVault, PKCS#11, SoftKMS).
 package certmaker
 
 import (
@@ -30,18 +30,18 @@ import (
 	"strings"
 
 	"github.com/sigstore/sigstore/pkg/signature"
-	"github.com/sigstore/sigstore/pkg/signature/kms"
 	"github.com/sigstore/sigstore/pkg/signature/options"
-
-	// Initialize AWS KMS provider
-	_ "github.com/sigstore/sigstore/pkg/signature/kms/aws"
-	// Initialize Azure KMS provider
-	_ "github.com/sigstore/sigstore/pkg/signature/kms/azure"
-	// Initialize GCP KMS provider
-	_ "github.com/sigstore/sigstore/pkg/signature/kms/gcp"
-	// Initialize HashiVault KMS provider
-	_ "github.com/sigstore/sigstore/pkg/signature/kms/hashivault"
 	"go.step.sm/crypto/x509util"
+
+	"github.com/ianhundere/fulcio/pkg/certmaker/provider"
+
+	// Register the built-in KMS/HSM providers.
+	_ "github.com/ianhundere/fulcio/pkg/certmaker/providers/awskms"
+	_ "github.com/ianhundere/fulcio/pkg/certmaker/providers/azurekms"
+	_ "github.com/ianhundere/fulcio/pkg/certmaker/providers/gcpkms"
+	_ "github.com/ianhundere/fulcio/pkg/certmaker/providers/hashivault"
+	_ "github.com/ianhundere/fulcio/pkg/certmaker/providers/pkcs11"
+	_ "github.com/ianhundere/fulcio/pkg/certmaker/providers/softkms"
 )
 
 type signerWrapper struct {
@@ -65,6 +65,84 @@ type KMSConfig struct {
 	IntermediateKeyID string
 	LeafKeyID         string
 	Options           map[string]string
+	// KeyURI, if set, is a canonical KMS URI (awskms://, gcpkms://,
+	// azurekms://, hashivault://, pkcs11:, softkms:) identifying the signing
+	// key, following the go.step.sm/crypto/kms/uri conventions. It takes
+	// precedence over Type/RootKeyID/LeafKeyID/Region when present; see
+	// ParseKMSURI. When unset, InitKMS derives an equivalent URI from the
+	// typed fields above for backward compatibility.
+	KeyURI string
+	// ChainOutputPath, if set, writes the full leaf -> intermediate -> root
+	// chain as a single concatenated PEM file.
+	ChainOutputPath string
+	// BundleOutputPath, if set, writes a Sigstore TUF-compatible trust root
+	// (intermediate -> root, no leaf) suitable for a TUF repository.
+	BundleOutputPath string
+	// ManifestOutputPath, if set, writes a JSON Manifest describing every
+	// certificate created, including fingerprints and the KMS URI each key
+	// came from.
+	ManifestOutputPath string
+}
+
+// Provider implements one KMS/HSM backend for certmaker. See
+// pkg/certmaker/provider for the full interface contract.
+type Provider = provider.Provider
+
+// RegisterKMSProvider registers a Provider under name (e.g. "awskms",
+// "pkcs11"), making it available to InitKMS and ValidateKMSConfig. Call it
+// from a provider package's init() function; the packages under
+// pkg/certmaker/providers/ do this for the built-in providers. Downstream
+// users can add their own (YubiKey, CAPI, Signatory, ...) the same way,
+// without forking this package.
+func RegisterKMSProvider(name string, p Provider) {
+	provider.Register(name, p)
+}
+
+// CreateKeys provisions the root, intermediate, and leaf keys named in
+// config (whichever of RootKeyID/IntermediateKeyID/LeafKeyID are set) with
+// Sigstore-appropriate settings for config.Type, e.g. ECC_NIST_P384 SIGN_VERIFY
+// in AWS KMS, or an EC-HSM P-384 key in Azure Key Vault. The provider for
+// config.Type must implement provider.KeyCreator; softkms and pkcs11 don't,
+// since their keys are expected to already exist.
+func CreateKeys(ctx context.Context, config KMSConfig) error {
+	if err := ValidateKMSConfig(config); err != nil {
+		return fmt.Errorf("invalid KMS configuration: %w", err)
+	}
+
+	p, ok := provider.Get(config.Type)
+	if !ok {
+		return fmt.Errorf("unsupported KMS type: %s", config.Type)
+	}
+	creator, ok := p.(provider.KeyCreator)
+	if !ok {
+		return fmt.Errorf("KMS provider %s does not support key creation", config.Type)
+	}
+
+	providerConfig := toProviderConfig(config)
+	for _, keyID := range []string{config.RootKeyID, config.IntermediateKeyID, config.LeafKeyID} {
+		if keyID == "" {
+			continue
+		}
+		if err := creator.CreateKey(ctx, providerConfig, keyID); err != nil {
+			return fmt.Errorf("failed to create KMS key %s: %w", keyID, err)
+		}
+	}
+
+	return nil
+}
+
+// toProviderConfig adapts a KMSConfig to the provider.Config the registry
+// deals in.
+func toProviderConfig(config KMSConfig) provider.Config {
+	return provider.Config{
+		Type:              config.Type,
+		Region:            config.Region,
+		RootKeyID:         config.RootKeyID,
+		IntermediateKeyID: config.IntermediateKeyID,
+		LeafKeyID:         config.LeafKeyID,
+		Options:           config.Options,
+		KeyURI:            config.KeyURI,
+	}
 }
 
 // InitKMS initializes KMS provider based on the given config, KMSConfig.
@@ -79,69 +157,29 @@ var InitKMS = func(ctx context.Context, config KMSConfig) (signature.SignerVerif
 		keyID = config.LeafKeyID
 	}
 
-	var sv signature.SignerVerifier
-	var err error
-
-	switch config.Type {
-	case "awskms":
-		ref := fmt.Sprintf("awskms:///%s", keyID)
-		if config.Region != "" {
-			os.Setenv("AWS_REGION", config.Region)
-		}
-		sv, err = kms.Get(ctx, ref, crypto.SHA256)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize AWS KMS: %w", err)
-		}
-
-	case "gcpkms":
-		ref := fmt.Sprintf("gcpkms://%s", keyID)
-		sv, err = kms.Get(ctx, ref, crypto.SHA256)
+	keyURI := config.KeyURI
+	if keyURI == "" {
+		var err error
+		keyURI, err = buildKeyURI(config, keyID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to initialize GCP KMS: %w", err)
-		}
-
-	case "azurekms":
-		keyURI := keyID
-		if strings.HasPrefix(keyID, "azurekms:name=") {
-			nameStart := strings.Index(keyID, "name=") + 5
-			vaultIndex := strings.Index(keyID, ";vault=")
-			if vaultIndex != -1 {
-				keyName := strings.TrimSpace(keyID[nameStart:vaultIndex])
-				vaultName := strings.TrimSpace(keyID[vaultIndex+7:])
-				keyURI = fmt.Sprintf("azurekms://%s.vault.azure.net/%s", vaultName, keyName)
-			}
-		}
-		if config.Options != nil && config.Options["tenant-id"] != "" {
-			os.Setenv("AZURE_TENANT_ID", config.Options["tenant-id"])
-			os.Setenv("AZURE_ADDITIONALLY_ALLOWED_TENANTS", "*")
-		}
-		os.Setenv("AZURE_AUTHORITY_HOST", "https://login.microsoftonline.com/")
-
-		sv, err = kms.Get(ctx, keyURI, crypto.SHA256)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize Azure KMS: %w", err)
-		}
-
-	case "hashivault":
-		keyURI := fmt.Sprintf("hashivault://%s", keyID)
-		if config.Options != nil {
-			if token := config.Options["token"]; token != "" {
-				os.Setenv("VAULT_TOKEN", token)
-			}
-			if addr := config.Options["address"]; addr != "" {
-				os.Setenv("VAULT_ADDR", addr)
-			}
+			return nil, fmt.Errorf("failed to derive KMS URI: %w", err)
 		}
+	}
 
-		sv, err = kms.Get(ctx, keyURI, crypto.SHA256)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize HashiVault KMS: %w", err)
-		}
+	scheme, parsedKeyID, opts, err := ParseKMSURI(keyURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse KMS URI: %w", err)
+	}
 
-	default:
-		return nil, fmt.Errorf("unsupported KMS type: %s", config.Type)
+	p, ok := provider.Get(scheme)
+	if !ok {
+		return nil, fmt.Errorf("unsupported KMS type: %s (registered providers: %v)", scheme, provider.Names())
 	}
 
+	providerConfig := toProviderConfig(config)
+	providerConfig.Options = mergeOptions(config.Options, opts)
+
+	sv, err := p.NewSigner(ctx, providerConfig, parsedKeyID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get KMS signer: %w", err)
 	}
@@ -183,6 +221,7 @@ func CreateCertificates(sv signature.SignerVerifier, config KMSConfig,
 
 	var signingCert *x509.Certificate
 	var signingKey crypto.Signer
+	var intermediateCert *x509.Certificate
 
 	if intermediateKeyID != "" {
 		// Create intermediate cert if key ID is provided
@@ -191,9 +230,12 @@ func CreateCertificates(sv signature.SignerVerifier, config KMSConfig,
 			return fmt.Errorf("error parsing intermediate template: %w", err)
 		}
 
-		// Initialize new KMS for intermediate key
-		intermediateConfig := config
-		intermediateConfig.RootKeyID = intermediateKeyID
+		// Initialize new KMS for intermediate key. For softkms this just
+		// reads a different PEM file off disk, not a fresh cloud session.
+		intermediateConfig, err := deriveRoleConfig(config, intermediateKeyID)
+		if err != nil {
+			return fmt.Errorf("error deriving intermediate KMS configuration: %w", err)
+		}
 		intermediateSV, err := InitKMS(context.Background(), intermediateConfig)
 		if err != nil {
 			return fmt.Errorf("error initializing intermediate KMS: %w", err)
@@ -204,9 +246,10 @@ func CreateCertificates(sv signature.SignerVerifier, config KMSConfig,
 			return fmt.Errorf("error getting intermediate public key: %w", err)
 		}
 
-		intermediateCert, err := x509util.CreateCertificate(intermediateTmpl, rootCert, intermediatePubKey, signerWrapper{sv})
-		if err != nil {
-			return fmt.Errorf("error creating intermediate certificate: %w", err)
+		var icErr error
+		intermediateCert, icErr = x509util.CreateCertificate(intermediateTmpl, rootCert, intermediatePubKey, signerWrapper{sv})
+		if icErr != nil {
+			return fmt.Errorf("error creating intermediate certificate: %w", icErr)
 		}
 
 		if err := WriteCertificateToFile(intermediateCert, intermediateCertPath); err != nil {
@@ -227,8 +270,10 @@ func CreateCertificates(sv signature.SignerVerifier, config KMSConfig,
 	}
 
 	// Initialize new KMS for leaf key
-	leafConfig := config
-	leafConfig.RootKeyID = config.LeafKeyID
+	leafConfig, err := deriveRoleConfig(config, config.LeafKeyID)
+	if err != nil {
+		return fmt.Errorf("error deriving leaf KMS configuration: %w", err)
+	}
 	leafSV, err := InitKMS(context.Background(), leafConfig)
 	if err != nil {
 		return fmt.Errorf("error initializing leaf KMS: %w", err)
@@ -248,9 +293,83 @@ func CreateCertificates(sv signature.SignerVerifier, config KMSConfig,
 		return fmt.Errorf("error writing leaf certificate: %w", err)
 	}
 
+	if config.ChainOutputPath != "" {
+		if err := WriteCertificateChainFile(config.ChainOutputPath, leafCert, intermediateCert, rootCert); err != nil {
+			return fmt.Errorf("error writing certificate chain: %w", err)
+		}
+	}
+
+	if config.BundleOutputPath != "" {
+		if err := WriteCertificateChainFile(config.BundleOutputPath, intermediateCert, rootCert); err != nil {
+			return fmt.Errorf("error writing trust bundle: %w", err)
+		}
+	}
+
+	if config.ManifestOutputPath != "" {
+		rootKeyURI, _ := resolveKeyURI(config, keyIDOrFallback(config.RootKeyID, config.LeafKeyID))
+		entries := []ManifestEntry{newManifestEntry("root", rootCertPath, rootCert, rootKeyURI)}
+		if intermediateCert != nil {
+			var intermediateKeyURI string
+			if intermediateKeyURIConfig, err := deriveRoleConfig(config, intermediateKeyID); err == nil {
+				intermediateKeyURI, _ = resolveKeyURI(intermediateKeyURIConfig, intermediateKeyID)
+			}
+			entries = append(entries, newManifestEntry("intermediate", intermediateCertPath, intermediateCert, intermediateKeyURI))
+		}
+		var leafKeyURI string
+		if leafKeyURIConfig, err := deriveRoleConfig(config, config.LeafKeyID); err == nil {
+			leafKeyURI, _ = resolveKeyURI(leafKeyURIConfig, config.LeafKeyID)
+		}
+		entries = append(entries, newManifestEntry("leaf", leafCertPath, leafCert, leafKeyURI))
+
+		if err := WriteManifestFile(config.ManifestOutputPath, entries); err != nil {
+			return fmt.Errorf("error writing certificate manifest: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// keyIDOrFallback returns keyID, or fallback if keyID is empty. Mirrors the
+// RootKeyID/LeafKeyID fallback InitKMS applies when resolving a key.
+func keyIDOrFallback(keyID, fallback string) string {
+	if keyID != "" {
+		return keyID
+	}
+	return fallback
+}
+
+// deriveRoleConfig adapts config to initialize a signer for one specific
+// role's key (root, intermediate, or leaf), identified by keyID. It sets
+// RootKeyID to keyID, since that's the field InitKMS/buildKeyURI key off of.
+//
+// When config was configured purely via the canonical KeyURI field (no
+// Type set), clearing KeyURI outright would leave nothing for buildKeyURI
+// to derive a per-role URI from. So if KeyURI is set, its scheme and
+// options are first copied onto Type/Options (and its region query
+// parameter onto Region, if Region is empty) before it's cleared, keeping
+// config's KeyURI-only callers working the same as typed-field callers.
+func deriveRoleConfig(config KMSConfig, keyID string) (KMSConfig, error) {
+	roleConfig := config
+	roleConfig.RootKeyID = keyID
+
+	if roleConfig.KeyURI == "" {
+		return roleConfig, nil
+	}
+
+	scheme, _, opts, err := ParseKMSURI(roleConfig.KeyURI)
+	if err != nil {
+		return KMSConfig{}, fmt.Errorf("error parsing KeyURI: %w", err)
+	}
+	roleConfig.Type = scheme
+	roleConfig.Options = mergeOptions(config.Options, opts)
+	if roleConfig.Region == "" {
+		roleConfig.Region = opts["region"]
+	}
+	roleConfig.KeyURI = ""
+
+	return roleConfig, nil
+}
+
 // WriteCertificateToFile writes an X.509 certificate to a PEM-encoded file
 func WriteCertificateToFile(cert *x509.Certificate, filename string) error {
 	certPEM := &pem.Block{
@@ -282,6 +401,10 @@ func WriteCertificateToFile(cert *x509.Certificate, filename string) error {
 
 // ValidateKMSConfig ensures all required KMS configuration parameters are present
 func ValidateKMSConfig(config KMSConfig) error {
+	if config.KeyURI != "" {
+		return validateKeyURI(config.KeyURI)
+	}
+
 	if config.Type == "" {
 		return fmt.Errorf("KMS type cannot be empty")
 	}
@@ -289,157 +412,12 @@ func ValidateKMSConfig(config KMSConfig) error {
 		return fmt.Errorf("at least one of RootKeyID or LeafKeyID must be specified")
 	}
 
-	switch config.Type {
-	case "awskms":
-		// AWS KMS validation
-		if config.Region == "" {
-			return fmt.Errorf("region is required for AWS KMS")
-		}
-		validateAWSKeyID := func(keyID, keyType string) error {
-			if keyID == "" {
-				return nil
-			}
-			switch {
-			case strings.HasPrefix(keyID, "arn:aws:kms:"):
-				parts := strings.Split(keyID, ":")
-				if len(parts) < 6 {
-					return fmt.Errorf("invalid AWS KMS ARN format for %s", keyType)
-				}
-				if parts[3] != config.Region {
-					return fmt.Errorf("region in ARN (%s) does not match configured region (%s)", parts[3], config.Region)
-				}
-			case strings.HasPrefix(keyID, "alias/"):
-				if strings.TrimPrefix(keyID, "alias/") == "" {
-					return fmt.Errorf("alias name cannot be empty for %s", keyType)
-				}
-			default:
-				return fmt.Errorf("awskms %s must start with 'arn:aws:kms:' or 'alias/'", keyType)
-			}
-			return nil
-		}
-		if err := validateAWSKeyID(config.RootKeyID, "RootKeyID"); err != nil {
-			return err
-		}
-		if err := validateAWSKeyID(config.IntermediateKeyID, "IntermediateKeyID"); err != nil {
-			return err
-		}
-		if err := validateAWSKeyID(config.LeafKeyID, "LeafKeyID"); err != nil {
-			return err
-		}
-
-	case "gcpkms":
-		// GCP KMS validation
-		validateGCPKeyID := func(keyID, keyType string) error {
-			if keyID == "" {
-				return nil
-			}
-			requiredComponents := []struct {
-				component string
-				message   string
-			}{
-				{"projects/", "must start with 'projects/'"},
-				{"/locations/", "must contain '/locations/'"},
-				{"/keyRings/", "must contain '/keyRings/'"},
-				{"/cryptoKeys/", "must contain '/cryptoKeys/'"},
-				{"/cryptoKeyVersions/", "must contain '/cryptoKeyVersions/'"},
-			}
-			for _, req := range requiredComponents {
-				if !strings.Contains(keyID, req.component) {
-					return fmt.Errorf("gcpkms %s %s", keyType, req.message)
-				}
-			}
-			return nil
-		}
-		if err := validateGCPKeyID(config.RootKeyID, "RootKeyID"); err != nil {
-			return err
-		}
-		if err := validateGCPKeyID(config.IntermediateKeyID, "IntermediateKeyID"); err != nil {
-			return err
-		}
-		if err := validateGCPKeyID(config.LeafKeyID, "LeafKeyID"); err != nil {
-			return err
-		}
-
-	case "azurekms":
-		// Azure KMS validation
-		if config.Options == nil {
-			return fmt.Errorf("options map is required for Azure KMS")
-		}
-		if config.Options["tenant-id"] == "" {
-			return fmt.Errorf("tenant-id is required for Azure KMS")
-		}
-		validateAzureKeyID := func(keyID, keyType string) error {
-			if keyID == "" {
-				return nil
-			}
-			if !strings.HasPrefix(keyID, "azurekms:name=") {
-				return fmt.Errorf("azurekms %s must start with 'azurekms:name='", keyType)
-			}
-			nameStart := strings.Index(keyID, "name=") + 5
-			vaultIndex := strings.Index(keyID, ";vault=")
-			if vaultIndex == -1 {
-				return fmt.Errorf("azurekms %s must contain ';vault=' parameter", keyType)
-			}
-			if strings.TrimSpace(keyID[nameStart:vaultIndex]) == "" {
-				return fmt.Errorf("key name cannot be empty for %s", keyType)
-			}
-			if strings.TrimSpace(keyID[vaultIndex+7:]) == "" {
-				return fmt.Errorf("vault name cannot be empty for %s", keyType)
-			}
-			return nil
-		}
-		if err := validateAzureKeyID(config.RootKeyID, "RootKeyID"); err != nil {
-			return err
-		}
-		if err := validateAzureKeyID(config.IntermediateKeyID, "IntermediateKeyID"); err != nil {
-			return err
-		}
-		if err := validateAzureKeyID(config.LeafKeyID, "LeafKeyID"); err != nil {
-			return err
-		}
-
-	case "hashivault":
-		// HashiVault KMS validation
-		if config.Options == nil {
-			return fmt.Errorf("options map is required for HashiVault KMS")
-		}
-		if config.Options["address"] == "" {
-			return fmt.Errorf("address is required for HashiVault KMS")
-		}
-		if config.Options["token"] == "" {
-			return fmt.Errorf("token is required for HashiVault KMS")
-		}
-		validateHashiVaultKeyID := func(keyID, keyType string) error {
-			if keyID == "" {
-				return nil
-			}
-			parts := strings.Split(keyID, "/")
-			if len(parts) < 3 {
-				return fmt.Errorf("hashivault %s must be in format: transit/keys/keyname", keyType)
-			}
-			if parts[0] != "transit" || parts[1] != "keys" {
-				return fmt.Errorf("hashivault %s must start with 'transit/keys/'", keyType)
-			}
-			if parts[2] == "" {
-				return fmt.Errorf("key name cannot be empty for %s", keyType)
-			}
-			return nil
-		}
-		if err := validateHashiVaultKeyID(config.RootKeyID, "RootKeyID"); err != nil {
-			return err
-		}
-		if err := validateHashiVaultKeyID(config.IntermediateKeyID, "IntermediateKeyID"); err != nil {
-			return err
-		}
-		if err := validateHashiVaultKeyID(config.LeafKeyID, "LeafKeyID"); err != nil {
-			return err
-		}
-
-	default:
-		return fmt.Errorf("unsupported KMS type: %s", config.Type)
+	p, ok := provider.Get(config.Type)
+	if !ok {
+		return fmt.Errorf("unsupported KMS type: %s (registered providers: %v)", config.Type, provider.Names())
 	}
 
-	return nil
+	return p.Validate(toProviderConfig(config))
 }
 
 // ValidateTemplatePath checks if the template file exists, has a .json extension,
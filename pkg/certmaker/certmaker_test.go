@@ -0,0 +1,110 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package certmaker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+
+	"github.com/ianhundere/fulcio/pkg/certmaker/provider"
+)
+
+// fakeKeyCreatorProvider is a test-only provider.Provider that also
+// implements provider.KeyCreator, recording every CreateKey call so tests
+// can assert on call order.
+type fakeKeyCreatorProvider struct {
+	created []string
+}
+
+func (*fakeKeyCreatorProvider) Validate(provider.Config) error { return nil }
+
+func (*fakeKeyCreatorProvider) NewSigner(context.Context, provider.Config, string) (signature.SignerVerifier, error) {
+	return nil, nil
+}
+
+func (f *fakeKeyCreatorProvider) CreateKey(_ context.Context, _ provider.Config, keyID string) error {
+	f.created = append(f.created, keyID)
+	return nil
+}
+
+// fakeProvider implements provider.Provider only, standing in for softkms
+// and pkcs11, which don't support CreateKeys.
+type fakeProvider struct{}
+
+func (fakeProvider) Validate(provider.Config) error { return nil }
+
+func (fakeProvider) NewSigner(context.Context, provider.Config, string) (signature.SignerVerifier, error) {
+	return nil, nil
+}
+
+func TestCreateKeys_CallsCreateKeyForEachConfiguredRole(t *testing.T) {
+	p := &fakeKeyCreatorProvider{}
+	provider.Register("faketest-creator", p)
+
+	config := KMSConfig{
+		Type:              "faketest-creator",
+		RootKeyID:         "root-key",
+		IntermediateKeyID: "intermediate-key",
+		LeafKeyID:         "leaf-key",
+	}
+	if err := CreateKeys(context.Background(), config); err != nil {
+		t.Fatalf("CreateKeys returned error: %v", err)
+	}
+
+	want := []string{"root-key", "intermediate-key", "leaf-key"}
+	if len(p.created) != len(want) {
+		t.Fatalf("CreateKey called for %v, want %v", p.created, want)
+	}
+	for i, keyID := range want {
+		if p.created[i] != keyID {
+			t.Errorf("CreateKey call %d = %q, want %q", i, p.created[i], keyID)
+		}
+	}
+}
+
+func TestCreateKeys_SkipsEmptyRoleKeyIDs(t *testing.T) {
+	p := &fakeKeyCreatorProvider{}
+	provider.Register("faketest-creator-partial", p)
+
+	config := KMSConfig{Type: "faketest-creator-partial", RootKeyID: "root-key"}
+	if err := CreateKeys(context.Background(), config); err != nil {
+		t.Fatalf("CreateKeys returned error: %v", err)
+	}
+	if len(p.created) != 1 || p.created[0] != "root-key" {
+		t.Fatalf("CreateKey calls = %v, want [root-key]", p.created)
+	}
+}
+
+func TestCreateKeys_ProviderWithoutKeyCreatorSupport(t *testing.T) {
+	provider.Register("faketest-nocreator", fakeProvider{})
+
+	config := KMSConfig{Type: "faketest-nocreator", RootKeyID: "root-key"}
+	err := CreateKeys(context.Background(), config)
+	if err == nil || !strings.Contains(err.Error(), "does not support key creation") {
+		t.Fatalf("CreateKeys() error = %v, want a \"does not support key creation\" error", err)
+	}
+}
+
+func TestCreateKeys_UnsupportedKMSType(t *testing.T) {
+	config := KMSConfig{Type: "faketest-does-not-exist", RootKeyID: "root-key"}
+	err := CreateKeys(context.Background(), config)
+	if err == nil || !strings.Contains(err.Error(), "unsupported KMS type") {
+		t.Fatalf("CreateKeys() error = %v, want an \"unsupported KMS type\" error", err)
+	}
+}
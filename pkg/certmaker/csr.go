@@ -0,0 +1,121 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package certmaker
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"go.step.sm/crypto/x509util"
+)
+
+// GenerateCSR creates a PEM-encoded PKCS#10 certificate signing request for
+// the key identified by config, using the Subject/SANs from templatePath.
+// It's meant for keys that live in a different KMS/HSM account, region, or
+// tenancy than the one used to sign them, e.g. an intermediate generated on
+// an offline HSM and carried to a root held in a cloud KMS.
+//
+// Only the Subject and SAN fields round-trip through the CSR:
+// CreateCertificatesFromCSR reads them back into the signed certificate, but
+// doesn't copy any other extension templatePath may have set, so those
+// aren't included here either.
+func GenerateCSR(ctx context.Context, config KMSConfig, templatePath string) ([]byte, error) {
+	sv, err := InitKMS(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing KMS for CSR: %w", err)
+	}
+
+	tmpl, err := ParseTemplate(templatePath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CSR template: %w", err)
+	}
+
+	csrTmpl := &x509.CertificateRequest{
+		Subject:        tmpl.Subject,
+		DNSNames:       tmpl.DNSNames,
+		EmailAddresses: tmpl.EmailAddresses,
+		IPAddresses:    tmpl.IPAddresses,
+		URIs:           tmpl.URIs,
+	}
+
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, csrTmpl, signerWrapper{sv})
+	if err != nil {
+		return nil, fmt.Errorf("error creating CSR: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}), nil
+}
+
+// CreateCertificatesFromCSR signs a PEM-encoded CSR with sv/signingCert and
+// writes the resulting certificate to certPath. Unlike CreateCertificates,
+// it doesn't require every key in the chain to be reachable from a single
+// KMSConfig at once: the CSR carries the subordinate key's public key and
+// identity, so the root (or intermediate) can live in a separate KMS/HSM
+// from the one that produced the CSR. Only the CSR's Subject and SAN fields
+// are copied onto the signed certificate; any other CSR extension is
+// ignored in favor of whatever templatePath specifies.
+func CreateCertificatesFromCSR(sv signature.SignerVerifier, signingCert *x509.Certificate,
+	csrPath, templatePath, certPath string) (*x509.Certificate, error) {
+
+	csrPEM, err := os.ReadFile(csrPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSR %s: %w", csrPath, err)
+	}
+
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("%s does not contain a PEM-encoded certificate request", csrPath)
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CSR %s: %w", csrPath, err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("CSR %s has an invalid signature: %w", csrPath, err)
+	}
+
+	tmpl, err := ParseTemplate(templatePath, signingCert)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %w", err)
+	}
+	tmpl.Subject = csr.Subject
+	tmpl.DNSNames = csr.DNSNames
+	tmpl.EmailAddresses = csr.EmailAddresses
+	tmpl.IPAddresses = csr.IPAddresses
+	tmpl.URIs = csr.URIs
+
+	parent := signingCert
+	if parent == nil {
+		parent = tmpl
+	}
+
+	cert, err := x509util.CreateCertificate(tmpl, parent, csr.PublicKey, signerWrapper{sv})
+	if err != nil {
+		return nil, fmt.Errorf("error creating certificate from CSR: %w", err)
+	}
+
+	if err := WriteCertificateToFile(cert, certPath); err != nil {
+		return nil, fmt.Errorf("error writing certificate: %w", err)
+	}
+
+	return cert, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package certmaker
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// newTestSigner returns an in-memory signature.SignerVerifier backed by an
+// ephemeral ECDSA key, standing in for a KMS/HSM-backed signer in tests that
+// don't need InitKMS.
+func newTestSigner(t *testing.T) signature.SignerVerifier {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate test key: %v", err)
+	}
+	sv, err := signature.LoadSignerVerifier(key, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("failed to load test signer: %v", err)
+	}
+	return sv
+}
+
+// TestCreateCertificatesFromCSR_RejectsInvalidSignature confirms a CSR whose
+// signature doesn't match its own body is rejected before any certificate is
+// generated. Like the missing-file case below, it fails before
+// CreateCertificatesFromCSR reaches ParseTemplate, which this repository snapshot doesn't
+// define; subject/SAN substitution can't be covered here until a template
+// parser lands.
+func TestCreateCertificatesFromCSR_RejectsInvalidSignature(t *testing.T) {
+	dir := t.TempDir()
+
+	csrKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CSR key: %v", err)
+	}
+	csrTmpl := &x509.CertificateRequest{Subject: pkix.Name{CommonName: "tampered"}}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, csrTmpl, csrKey)
+	if err != nil {
+		t.Fatalf("failed to create CSR: %v", err)
+	}
+
+	// Flip a byte in the DER encoding so the embedded signature no longer
+	// matches the signed portion, without corrupting the ASN.1 framing
+	// enough to fail parsing outright.
+	csrDER[len(csrDER)-1] ^= 0xFF
+
+	csrPath := filepath.Join(dir, "tampered.csr")
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+	if err := os.WriteFile(csrPath, csrPEM, 0600); err != nil {
+		t.Fatalf("failed to write CSR: %v", err)
+	}
+
+	sv := newTestSigner(t)
+	certPath := filepath.Join(dir, "out.pem")
+	if _, err := CreateCertificatesFromCSR(sv, nil, csrPath, "unused.json", certPath); err == nil {
+		t.Fatal("expected an error for a CSR with an invalid signature")
+	}
+	if _, err := os.Stat(certPath); !os.IsNotExist(err) {
+		t.Fatalf("no certificate should have been written for an invalid CSR, got err=%v", err)
+	}
+}
+
+func TestCreateCertificatesFromCSR_MissingFile(t *testing.T) {
+	sv := newTestSigner(t)
+	if _, err := CreateCertificatesFromCSR(sv, nil, "/nonexistent/path.csr", "unused.json", "/dev/null"); err == nil {
+		t.Fatal("expected an error for a missing CSR file")
+	}
+}
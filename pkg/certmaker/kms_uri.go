@@ -0,0 +1,234 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package certmaker
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ParseKMSURI parses a canonical KMS URI into its scheme, key identifier,
+// and options, following the go.step.sm/crypto/kms/uri and sigstore KMS URI
+// conventions. Supported schemes are awskms://, gcpkms://, azurekms://,
+// hashivault://, pkcs11:, and softkms:. Query parameters (or, for pkcs11,
+// semicolon-separated components) are returned as opts so callers don't
+// need to know each scheme's URI shape.
+func ParseKMSURI(raw string) (scheme, keyID string, opts map[string]string, err error) {
+	opts = map[string]string{}
+
+	if strings.HasPrefix(raw, "pkcs11:") {
+		for _, part := range strings.Split(strings.TrimPrefix(raw, "pkcs11:"), ";") {
+			if part == "" {
+				continue
+			}
+			kv := strings.SplitN(part, "=", 2)
+			if len(kv) != 2 {
+				return "", "", nil, fmt.Errorf("invalid pkcs11 URI component %q", part)
+			}
+			opts[kv[0]] = kv[1]
+		}
+		return "pkcs11", opts["object"], opts, nil
+	}
+
+	if strings.HasPrefix(raw, "softkms:") {
+		path := strings.TrimPrefix(raw, "softkms:")
+		if idx := strings.Index(path, "?"); idx != -1 {
+			query, qerr := url.ParseQuery(path[idx+1:])
+			if qerr != nil {
+				return "", "", nil, fmt.Errorf("invalid softkms URI query: %w", qerr)
+			}
+			for k := range query {
+				opts[k] = query.Get(k)
+			}
+			path = path[:idx]
+		}
+		return "softkms", path, opts, nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("invalid KMS URI %q: %w", raw, err)
+	}
+	scheme = strings.TrimSuffix(u.Scheme, ":")
+	if scheme == "" {
+		return "", "", nil, fmt.Errorf("KMS URI %q has no scheme", raw)
+	}
+	for k := range u.Query() {
+		opts[k] = u.Query().Get(k)
+	}
+
+	keyID = strings.TrimPrefix(u.Host+u.Path, "/")
+
+	return scheme, keyID, opts, nil
+}
+
+// buildKeyURI translates the legacy, provider-specific KMSConfig fields into
+// a canonical KeyURI so InitKMS can operate on a single URI form regardless
+// of whether the caller set KeyURI directly or used the older typed fields.
+// ValidateKMSConfig does not use it: typed fields are checked by the
+// provider's Validate.
+func buildKeyURI(config KMSConfig, keyID string) (string, error) {
+	switch config.Type {
+	case "awskms":
+		uri := fmt.Sprintf("awskms:///%s", keyID)
+		if config.Region != "" {
+			uri += "?region=" + url.QueryEscape(config.Region)
+		}
+		return uri, nil
+
+	case "gcpkms":
+		return fmt.Sprintf("gcpkms://%s", keyID), nil
+
+	case "azurekms":
+		if !strings.HasPrefix(keyID, "azurekms:name=") {
+			return fmt.Sprintf("azurekms://%s", strings.TrimPrefix(keyID, "azurekms://")), nil
+		}
+		nameStart := strings.Index(keyID, "name=") + 5
+		vaultIndex := strings.Index(keyID, ";vault=")
+		if vaultIndex == -1 {
+			return "", fmt.Errorf("azurekms key ID missing ';vault=' parameter")
+		}
+		keyName := strings.TrimSpace(keyID[nameStart:vaultIndex])
+		vaultName := strings.TrimSpace(keyID[vaultIndex+7:])
+		uri := fmt.Sprintf("azurekms://%s.vault.azure.net/%s", vaultName, keyName)
+		if config.Options["tenant-id"] != "" {
+			uri += "?tenant-id=" + url.QueryEscape(config.Options["tenant-id"])
+		}
+		return uri, nil
+
+	case "hashivault":
+		return fmt.Sprintf("hashivault://%s", keyID), nil
+
+	case "pkcs11":
+		object := keyID
+		if object == "" {
+			object = config.Options["object"]
+		}
+
+		var b strings.Builder
+		b.WriteString("pkcs11:")
+		first := true
+		for _, k := range []string{"module", "slot-id", "token", "pin", "pin-source"} {
+			if v := config.Options[k]; v != "" {
+				if !first {
+					b.WriteString(";")
+				}
+				fmt.Fprintf(&b, "%s=%s", k, v)
+				first = false
+			}
+		}
+		if object != "" {
+			if !first {
+				b.WriteString(";")
+			}
+			fmt.Fprintf(&b, "object=%s", object)
+		}
+		return b.String(), nil
+
+	case "softkms", "file":
+		uri := "softkms:" + keyID
+		if config.Options["password"] != "" {
+			uri += "?password=" + url.QueryEscape(config.Options["password"])
+		}
+		return uri, nil
+
+	default:
+		return "", fmt.Errorf("unsupported KMS type: %s", config.Type)
+	}
+}
+
+// validateKeyURI checks that a KeyURI is well-formed and carries the
+// parameters its scheme requires, mirroring the per-type checks
+// ValidateKMSConfig applies to the legacy typed fields.
+func validateKeyURI(raw string) error {
+	scheme, keyID, opts, err := ParseKMSURI(raw)
+	if err != nil {
+		return fmt.Errorf("invalid KeyURI: %w", err)
+	}
+
+	switch scheme {
+	case "awskms", "gcpkms", "softkms", "file":
+		if keyID == "" {
+			return fmt.Errorf("KeyURI %q is missing a key identifier", raw)
+		}
+
+	case "azurekms":
+		if keyID == "" {
+			return fmt.Errorf("KeyURI %q is missing a key identifier", raw)
+		}
+		if opts["tenant-id"] == "" {
+			return fmt.Errorf("KeyURI %q must specify a tenant-id parameter", raw)
+		}
+
+	case "hashivault":
+		if keyID == "" {
+			return fmt.Errorf("KeyURI %q is missing a key identifier", raw)
+		}
+		if opts["address"] == "" {
+			return fmt.Errorf("KeyURI %q must specify an address parameter", raw)
+		}
+		if opts["token"] == "" {
+			return fmt.Errorf("KeyURI %q must specify a token parameter", raw)
+		}
+
+	case "pkcs11":
+		if opts["module"] == "" {
+			return fmt.Errorf("KeyURI %q must specify a module parameter", raw)
+		}
+		if opts["slot-id"] == "" && opts["token"] == "" {
+			return fmt.Errorf("KeyURI %q must specify a slot-id or token parameter", raw)
+		}
+		if opts["pin"] == "" && opts["pin-source"] == "" && os.Getenv("FULCIO_PKCS11_PIN") == "" {
+			return fmt.Errorf("KeyURI %q must specify a pin or pin-source parameter", raw)
+		}
+		if opts["object"] == "" {
+			return fmt.Errorf("KeyURI %q must specify an object parameter to select a PKCS#11 key", raw)
+		}
+
+	default:
+		return fmt.Errorf("unsupported KMS URI scheme: %s", scheme)
+	}
+
+	return nil
+}
+
+// resolveKeyURI returns config.KeyURI if set, otherwise derives one from
+// the legacy typed fields via buildKeyURI. Errors are non-fatal for
+// callers that only want the URI as descriptive metadata (e.g. a
+// certificate manifest); such callers should treat a returned error as "no
+// URI available" rather than failing the whole operation.
+func resolveKeyURI(config KMSConfig, keyID string) (string, error) {
+	if config.KeyURI != "" {
+		return config.KeyURI, nil
+	}
+	return buildKeyURI(config, keyID)
+}
+
+// mergeOptions returns a new map containing base overlaid with override;
+// override wins on key collisions. Used to reconcile KMSConfig.Options with
+// parameters carried in a KeyURI.
+func mergeOptions(base, override map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
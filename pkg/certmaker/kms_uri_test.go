@@ -0,0 +1,263 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package certmaker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKMSURI(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		wantScheme string
+		wantKeyID  string
+		wantOpts   map[string]string
+	}{
+		{
+			name:       "awskms",
+			raw:        "awskms:///alias/fulcio-root?region=us-east-1",
+			wantScheme: "awskms",
+			wantKeyID:  "alias/fulcio-root",
+			wantOpts:   map[string]string{"region": "us-east-1"},
+		},
+		{
+			name:       "gcpkms",
+			raw:        "gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1",
+			wantScheme: "gcpkms",
+			wantKeyID:  "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1",
+			wantOpts:   map[string]string{},
+		},
+		{
+			name:       "azurekms",
+			raw:        "azurekms://myvault.vault.azure.net/mykey?tenant-id=abc",
+			wantScheme: "azurekms",
+			wantKeyID:  "myvault.vault.azure.net/mykey",
+			wantOpts:   map[string]string{"tenant-id": "abc"},
+		},
+		{
+			name:       "hashivault",
+			raw:        "hashivault://transit/keys/mykey",
+			wantScheme: "hashivault",
+			wantKeyID:  "transit/keys/mykey",
+			wantOpts:   map[string]string{},
+		},
+		{
+			name:       "pkcs11",
+			raw:        "pkcs11:module=/usr/lib/softhsm.so;token=fulcio;object=root-key;pin=1234",
+			wantScheme: "pkcs11",
+			wantKeyID:  "root-key",
+			wantOpts: map[string]string{
+				"module": "/usr/lib/softhsm.so",
+				"token":  "fulcio",
+				"object": "root-key",
+				"pin":    "1234",
+			},
+		},
+		{
+			name:       "softkms",
+			raw:        "softkms:/path/to/key.pem?password=hunter2",
+			wantScheme: "softkms",
+			wantKeyID:  "/path/to/key.pem",
+			wantOpts:   map[string]string{"password": "hunter2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, keyID, opts, err := ParseKMSURI(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseKMSURI(%q) returned error: %v", tt.raw, err)
+			}
+			if scheme != tt.wantScheme {
+				t.Errorf("scheme = %q, want %q", scheme, tt.wantScheme)
+			}
+			if keyID != tt.wantKeyID {
+				t.Errorf("keyID = %q, want %q", keyID, tt.wantKeyID)
+			}
+			for k, want := range tt.wantOpts {
+				if got := opts[k]; got != want {
+					t.Errorf("opts[%q] = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseKMSURI_InvalidPKCS11Component(t *testing.T) {
+	if _, _, _, err := ParseKMSURI("pkcs11:module"); err == nil {
+		t.Fatal("expected an error for a pkcs11 URI component without '='")
+	}
+}
+
+func TestValidateKeyURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{
+			name: "pkcs11 without object is rejected",
+			raw:  "pkcs11:module=/usr/lib/softhsm.so;token=fulcio;pin=1234",
+			// Without an object parameter there's nothing to select a
+			// specific HSM key pair; NewSigner would look up an empty label.
+			wantErr: true,
+		},
+		{
+			name:    "pkcs11 with object is accepted",
+			raw:     "pkcs11:module=/usr/lib/softhsm.so;token=fulcio;pin=1234;object=root-key",
+			wantErr: false,
+		},
+		{
+			name:    "azurekms without tenant-id is rejected",
+			raw:     "azurekms://myvault.vault.azure.net/mykey",
+			wantErr: true,
+		},
+		{
+			name:    "azurekms with tenant-id is accepted",
+			raw:     "azurekms://myvault.vault.azure.net/mykey?tenant-id=abc",
+			wantErr: false,
+		},
+		{
+			name:    "hashivault without address or token is rejected",
+			raw:     "hashivault://transit/keys/mykey",
+			wantErr: true,
+		},
+		{
+			name:    "hashivault without token is rejected",
+			raw:     "hashivault://transit/keys/mykey?address=https://vault.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "hashivault with address and token is accepted",
+			raw:     "hashivault://transit/keys/mykey?address=https://vault.example.com&token=s.abc",
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKeyURI(tt.raw)
+			if tt.wantErr && err == nil {
+				t.Fatalf("validateKeyURI(%q) = nil, want an error", tt.raw)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("validateKeyURI(%q) returned unexpected error: %v", tt.raw, err)
+			}
+		})
+	}
+}
+
+func TestBuildKeyURI(t *testing.T) {
+	tests := []struct {
+		name   string
+		config KMSConfig
+		keyID  string
+		want   string
+	}{
+		{
+			name:   "awskms with region",
+			config: KMSConfig{Type: "awskms", Region: "us-east-1"},
+			keyID:  "alias/fulcio-root",
+			want:   "awskms:///alias/fulcio-root?region=us-east-1",
+		},
+		{
+			name:   "gcpkms",
+			config: KMSConfig{Type: "gcpkms"},
+			keyID:  "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1",
+			want:   "gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1",
+		},
+		{
+			name:   "hashivault",
+			config: KMSConfig{Type: "hashivault"},
+			keyID:  "transit/keys/mykey",
+			want:   "hashivault://transit/keys/mykey",
+		},
+		{
+			name:   "softkms",
+			config: KMSConfig{Type: "softkms"},
+			keyID:  "/path/to/key.pem",
+			want:   "softkms:/path/to/key.pem",
+		},
+		{
+			name: "azurekms legacy typed fields translate to a scheme-prefixed host/path URI",
+			config: KMSConfig{
+				Type:    "azurekms",
+				Options: map[string]string{"tenant-id": "abc"},
+			},
+			keyID: "azurekms:name=mykey;vault=myvault",
+			want:  "azurekms://myvault.vault.azure.net/mykey?tenant-id=abc",
+		},
+		{
+			name: "pkcs11 honors keyID as the object label over Options[\"object\"]",
+			config: KMSConfig{
+				Type:    "pkcs11",
+				Options: map[string]string{"module": "/usr/lib/softhsm.so", "token": "fulcio", "object": "ignored"},
+			},
+			keyID: "intermediate-key",
+			want:  "pkcs11:module=/usr/lib/softhsm.so;token=fulcio;object=intermediate-key",
+		},
+		{
+			name: "pkcs11 falls back to Options[\"object\"] when keyID is empty",
+			config: KMSConfig{
+				Type:    "pkcs11",
+				Options: map[string]string{"module": "/usr/lib/softhsm.so", "token": "fulcio", "object": "root-key"},
+			},
+			keyID: "",
+			want:  "pkcs11:module=/usr/lib/softhsm.so;token=fulcio;object=root-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildKeyURI(tt.config, tt.keyID)
+			if err != nil {
+				t.Fatalf("buildKeyURI() returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("buildKeyURI() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBuildKeyURI_AzureRoundTrip guards against the regression where a
+// legacy "azurekms:name=...;vault=..." typed config built into a canonical
+// URI, then parsed by ParseKMSURI, came back as a bare "host/path" string
+// with no scheme left for Provider.NewSigner to reconstruct.
+func TestBuildKeyURI_AzureRoundTrip(t *testing.T) {
+	config := KMSConfig{Type: "azurekms", Options: map[string]string{"tenant-id": "abc"}}
+
+	uri, err := buildKeyURI(config, "azurekms:name=mykey;vault=myvault")
+	if err != nil {
+		t.Fatalf("buildKeyURI() returned error: %v", err)
+	}
+
+	scheme, keyID, _, err := ParseKMSURI(uri)
+	if err != nil {
+		t.Fatalf("ParseKMSURI(%q) returned error: %v", uri, err)
+	}
+	if scheme != "azurekms" {
+		t.Fatalf("scheme = %q, want %q", scheme, "azurekms")
+	}
+	if strings.Contains(keyID, "azurekms:") {
+		t.Fatalf("keyID %q still carries a scheme prefix; NewSigner would fail to reconstruct a valid URI", keyID)
+	}
+	if keyID != "myvault.vault.azure.net/mykey" {
+		t.Fatalf("keyID = %q, want %q", keyID, "myvault.vault.azure.net/mykey")
+	}
+}
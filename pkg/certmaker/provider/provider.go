@@ -0,0 +1,97 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package provider defines the pluggable KMS/HSM backend interface used by
+// pkg/certmaker, along with the registry that backs certmaker.RegisterKMSProvider.
+// It exists as its own package, separate from certmaker, so that provider
+// implementations under pkg/certmaker/providers/ can import it without
+// creating an import cycle with certmaker itself.
+package provider
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// Config carries the per-key parameters a Provider needs to validate
+// itself and produce a signer. It mirrors certmaker.KMSConfig's fields.
+type Config struct {
+	Type              string
+	Region            string
+	RootKeyID         string
+	IntermediateKeyID string
+	LeafKeyID         string
+	Options           map[string]string
+	KeyURI            string
+}
+
+// Provider implements one KMS/HSM backend for certmaker, e.g. awskms or
+// pkcs11. Implementations self-register via Register in an init() func.
+type Provider interface {
+	// Validate checks that config carries everything this provider needs
+	// (region, credentials, key ID formats, etc.) before a signer is used.
+	Validate(config Config) error
+	// NewSigner returns a signer for keyID, resolved from config according
+	// to the provider's own key identifier conventions.
+	NewSigner(ctx context.Context, config Config, keyID string) (signature.SignerVerifier, error)
+}
+
+// KeyCreator is implemented by providers that can provision a new signing
+// key with Sigstore-appropriate settings (algorithm, protection level,
+// rotation/export policy), rather than only signing with a key the
+// operator already created. softkms and pkcs11 don't implement this: their
+// keys are expected to already exist on disk or on the HSM.
+type KeyCreator interface {
+	// CreateKey provisions keyID in the KMS/HSM backing this provider.
+	CreateKey(ctx context.Context, config Config, keyID string) error
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{}
+)
+
+// Register adds p to the registry under name (e.g. "awskms", "pkcs11").
+// Provider packages call this from their init() function; registering the
+// same name twice replaces the previous entry.
+func Register(name string, p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[name] = p
+}
+
+// Get looks up a previously registered provider by name.
+func Get(name string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	return p, ok
+}
+
+// Names returns the names of all currently registered providers, sorted,
+// for use in "unsupported KMS type" error messages.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
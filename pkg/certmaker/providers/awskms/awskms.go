@@ -0,0 +1,135 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package awskms registers the "awskms" certmaker provider, backed by
+// AWS KMS via sigstore's signature/kms/aws implementation.
+package awskms
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"strings"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/sigstore/sigstore/pkg/signature"
+	sigkms "github.com/sigstore/sigstore/pkg/signature/kms"
+	// Initialize AWS KMS provider
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/aws"
+
+	"github.com/ianhundere/fulcio/pkg/certmaker/provider"
+)
+
+func init() {
+	provider.Register("awskms", &awsProvider{})
+}
+
+type awsProvider struct{}
+
+func (awsProvider) Validate(config provider.Config) error {
+	if config.Region == "" {
+		return fmt.Errorf("region is required for AWS KMS")
+	}
+
+	validateKeyID := func(keyID, keyType string) error {
+		if keyID == "" {
+			return nil
+		}
+		switch {
+		case strings.HasPrefix(keyID, "arn:aws:kms:"):
+			parts := strings.Split(keyID, ":")
+			if len(parts) < 6 {
+				return fmt.Errorf("invalid AWS KMS ARN format for %s", keyType)
+			}
+			if parts[3] != config.Region {
+				return fmt.Errorf("region in ARN (%s) does not match configured region (%s)", parts[3], config.Region)
+			}
+		case strings.HasPrefix(keyID, "alias/"):
+			if strings.TrimPrefix(keyID, "alias/") == "" {
+				return fmt.Errorf("alias name cannot be empty for %s", keyType)
+			}
+		default:
+			return fmt.Errorf("awskms %s must start with 'arn:aws:kms:' or 'alias/'", keyType)
+		}
+		return nil
+	}
+
+	if err := validateKeyID(config.RootKeyID, "RootKeyID"); err != nil {
+		return err
+	}
+	if err := validateKeyID(config.IntermediateKeyID, "IntermediateKeyID"); err != nil {
+		return err
+	}
+	if err := validateKeyID(config.LeafKeyID, "LeafKeyID"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (awsProvider) NewSigner(ctx context.Context, config provider.Config, keyID string) (signature.SignerVerifier, error) {
+	ref := fmt.Sprintf("awskms:///%s", keyID)
+	if region := config.Options["region"]; region != "" {
+		os.Setenv("AWS_REGION", region)
+	} else if config.Region != "" {
+		os.Setenv("AWS_REGION", config.Region)
+	}
+
+	sv, err := sigkms.Get(ctx, ref, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize AWS KMS: %w", err)
+	}
+	return sv, nil
+}
+
+// CreateKey provisions keyID (an alias, e.g. "alias/fulcio-root") as a new
+// asymmetric AWS KMS key with the algorithm Sigstore requires: ECC_NIST_P384
+// sign/verify. MultiRegion is enabled when Options["multi-region"] is "true",
+// for replicating root/intermediate keys across regions.
+func (awsProvider) CreateKey(ctx context.Context, config provider.Config, keyID string) error {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(config.Region))
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	client := kms.NewFromConfig(cfg)
+
+	multiRegion := config.Options["multi-region"] == "true"
+	out, err := client.CreateKey(ctx, &kms.CreateKeyInput{
+		KeyUsage:    types.KeyUsageTypeSignVerify,
+		KeySpec:     types.KeySpecEccNistP384,
+		MultiRegion: &multiRegion,
+		Description: strPtr(fmt.Sprintf("Sigstore Fulcio signing key (%s)", keyID)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create AWS KMS key for %s: %w", keyID, err)
+	}
+
+	if !strings.HasPrefix(keyID, "alias/") {
+		return nil
+	}
+	if _, err := client.CreateAlias(ctx, &kms.CreateAliasInput{
+		AliasName:   &keyID,
+		TargetKeyId: out.KeyMetadata.KeyId,
+	}); err != nil {
+		return fmt.Errorf("failed to create AWS KMS alias %s: %w", keyID, err)
+	}
+
+	return nil
+}
+
+func strPtr(s string) *string { return &s }
@@ -0,0 +1,143 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package azurekms registers the "azurekms" certmaker provider, backed by
+// Azure Key Vault via sigstore's signature/kms/azure implementation.
+package azurekms
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/sigstore/sigstore/pkg/signature"
+	sigkms "github.com/sigstore/sigstore/pkg/signature/kms"
+	// Initialize Azure KMS provider
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/azure"
+
+	"github.com/ianhundere/fulcio/pkg/certmaker/provider"
+)
+
+func init() {
+	provider.Register("azurekms", &azureProvider{})
+}
+
+type azureProvider struct{}
+
+func (azureProvider) Validate(config provider.Config) error {
+	if config.Options == nil {
+		return fmt.Errorf("options map is required for Azure KMS")
+	}
+	if config.Options["tenant-id"] == "" {
+		return fmt.Errorf("tenant-id is required for Azure KMS")
+	}
+
+	validateKeyID := func(keyID, keyType string) error {
+		if keyID == "" {
+			return nil
+		}
+		if !strings.HasPrefix(keyID, "azurekms:name=") {
+			return fmt.Errorf("azurekms %s must start with 'azurekms:name='", keyType)
+		}
+		nameStart := strings.Index(keyID, "name=") + 5
+		vaultIndex := strings.Index(keyID, ";vault=")
+		if vaultIndex == -1 {
+			return fmt.Errorf("azurekms %s must contain ';vault=' parameter", keyType)
+		}
+		if strings.TrimSpace(keyID[nameStart:vaultIndex]) == "" {
+			return fmt.Errorf("key name cannot be empty for %s", keyType)
+		}
+		if strings.TrimSpace(keyID[vaultIndex+7:]) == "" {
+			return fmt.Errorf("vault name cannot be empty for %s", keyType)
+		}
+		return nil
+	}
+
+	if err := validateKeyID(config.RootKeyID, "RootKeyID"); err != nil {
+		return err
+	}
+	if err := validateKeyID(config.IntermediateKeyID, "IntermediateKeyID"); err != nil {
+		return err
+	}
+	if err := validateKeyID(config.LeafKeyID, "LeafKeyID"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (azureProvider) NewSigner(ctx context.Context, config provider.Config, keyID string) (signature.SignerVerifier, error) {
+	// By the time NewSigner is called, keyID has already been through
+	// ParseKMSURI/buildKeyURI and is always the scheme-stripped
+	// "<vault-host>/<key-name>" form (legacy "azurekms:name=...;vault=..."
+	// configs are translated to this form by buildKeyURI before parsing).
+	// Re-add the scheme prefix the same way awskms/gcpkms/hashivault do.
+	keyURI := "azurekms://" + strings.TrimPrefix(keyID, "azurekms://")
+
+	if tenantID := config.Options["tenant-id"]; tenantID != "" {
+		os.Setenv("AZURE_TENANT_ID", tenantID)
+		os.Setenv("AZURE_ADDITIONALLY_ALLOWED_TENANTS", "*")
+	}
+	os.Setenv("AZURE_AUTHORITY_HOST", "https://login.microsoftonline.com/")
+
+	sv, err := sigkms.Get(ctx, keyURI, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Azure KMS: %w", err)
+	}
+	return sv, nil
+}
+
+// CreateKey provisions keyID ("azurekms:name=<key>;vault=<vault>") as a new
+// EC-HSM P-384 key in the vault, the combination Sigstore requires. The
+// vault itself is expected to already be a Premium-tier vault with purge
+// protection enabled; CreateKey does not provision infrastructure.
+func (azureProvider) CreateKey(ctx context.Context, config provider.Config, keyID string) error {
+	if !strings.HasPrefix(keyID, "azurekms:name=") {
+		return fmt.Errorf("azurekms key %s must start with 'azurekms:name='", keyID)
+	}
+	nameStart := strings.Index(keyID, "name=") + 5
+	vaultIndex := strings.Index(keyID, ";vault=")
+	if vaultIndex == -1 {
+		return fmt.Errorf("azurekms key %s must contain ';vault=' parameter", keyID)
+	}
+	keyName := strings.TrimSpace(keyID[nameStart:vaultIndex])
+	vaultName := strings.TrimSpace(keyID[vaultIndex+7:])
+
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return fmt.Errorf("failed to load Azure credentials: %w", err)
+	}
+
+	client, err := azkeys.NewClient(fmt.Sprintf("https://%s.vault.azure.net", vaultName), cred, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Azure Key Vault client: %w", err)
+	}
+
+	_, err = client.CreateKey(ctx, keyName, azkeys.CreateKeyParameters{
+		Kty:    to.Ptr(azkeys.JSONWebKeyTypeECHSM),
+		Curve:  to.Ptr(azkeys.JSONWebKeyCurveNameP384),
+		KeyOps: []*azkeys.JSONWebKeyOperation{to.Ptr(azkeys.JSONWebKeyOperationSign), to.Ptr(azkeys.JSONWebKeyOperationVerify)},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Azure Key Vault key %s in vault %s: %w", keyName, vaultName, err)
+	}
+
+	return nil
+}
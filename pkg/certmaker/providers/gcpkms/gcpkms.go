@@ -0,0 +1,136 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package gcpkms registers the "gcpkms" certmaker provider, backed by
+// Google Cloud KMS via sigstore's signature/kms/gcp implementation.
+package gcpkms
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"strings"
+
+	kmsapi "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/sigstore/sigstore/pkg/signature"
+	sigkms "github.com/sigstore/sigstore/pkg/signature/kms"
+	// Initialize GCP KMS provider
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/gcp"
+
+	"github.com/ianhundere/fulcio/pkg/certmaker/provider"
+)
+
+func init() {
+	provider.Register("gcpkms", &gcpProvider{})
+}
+
+type gcpProvider struct{}
+
+func (gcpProvider) Validate(config provider.Config) error {
+	validateKeyID := func(keyID, keyType string) error {
+		if keyID == "" {
+			return nil
+		}
+		requiredComponents := []struct {
+			component string
+			message   string
+		}{
+			{"projects/", "must start with 'projects/'"},
+			{"/locations/", "must contain '/locations/'"},
+			{"/keyRings/", "must contain '/keyRings/'"},
+			{"/cryptoKeys/", "must contain '/cryptoKeys/'"},
+			{"/cryptoKeyVersions/", "must contain '/cryptoKeyVersions/'"},
+		}
+		for _, req := range requiredComponents {
+			if !strings.Contains(keyID, req.component) {
+				return fmt.Errorf("gcpkms %s %s", keyType, req.message)
+			}
+		}
+		return nil
+	}
+
+	if err := validateKeyID(config.RootKeyID, "RootKeyID"); err != nil {
+		return err
+	}
+	if err := validateKeyID(config.IntermediateKeyID, "IntermediateKeyID"); err != nil {
+		return err
+	}
+	if err := validateKeyID(config.LeafKeyID, "LeafKeyID"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (gcpProvider) NewSigner(ctx context.Context, _ provider.Config, keyID string) (signature.SignerVerifier, error) {
+	ref := fmt.Sprintf("gcpkms://%s", keyID)
+	sv, err := sigkms.Get(ctx, ref, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize GCP KMS: %w", err)
+	}
+	return sv, nil
+}
+
+// CreateKey provisions keyID's CryptoKey (a .../cryptoKeys/<name> or
+// .../cryptoKeys/<name>/cryptoKeyVersions/<n> resource name) with the
+// algorithm and protection level Sigstore requires: EC_SIGN_P384_SHA384 in
+// an HSM, with automatic rotation disabled so Fulcio controls key lifetime.
+func (gcpProvider) CreateKey(ctx context.Context, _ provider.Config, keyID string) error {
+	client, err := kmsapi.NewKeyManagementClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create GCP KMS client: %w", err)
+	}
+	defer client.Close()
+
+	keyRing, cryptoKeyID, err := splitCryptoKeyName(keyID)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.CreateCryptoKey(ctx, &kmspb.CreateCryptoKeyRequest{
+		Parent:      keyRing,
+		CryptoKeyId: cryptoKeyID,
+		CryptoKey: &kmspb.CryptoKey{
+			Purpose: kmspb.CryptoKey_ASYMMETRIC_SIGN,
+			VersionTemplate: &kmspb.CryptoKeyVersionTemplate{
+				Algorithm:       kmspb.CryptoKeyVersion_EC_SIGN_P384_SHA384,
+				ProtectionLevel: kmspb.ProtectionLevel_HSM,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create GCP KMS key %s: %w", keyID, err)
+	}
+
+	return nil
+}
+
+// splitCryptoKeyName splits a GCP KMS CryptoKey (or CryptoKeyVersion)
+// resource name into its parent key ring and crypto key ID, as required by
+// CreateCryptoKeyRequest.
+func splitCryptoKeyName(name string) (keyRing, cryptoKeyID string, err error) {
+	idx := strings.Index(name, "/cryptoKeys/")
+	if idx == -1 {
+		return "", "", fmt.Errorf("gcpkms key %s is missing '/cryptoKeys/'", name)
+	}
+	keyRing = name[:idx]
+	rest := name[idx+len("/cryptoKeys/"):]
+	cryptoKeyID = strings.SplitN(rest, "/", 2)[0]
+	if cryptoKeyID == "" {
+		return "", "", fmt.Errorf("gcpkms key %s has an empty crypto key ID", name)
+	}
+	return keyRing, cryptoKeyID, nil
+}
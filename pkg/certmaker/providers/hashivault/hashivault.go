@@ -0,0 +1,131 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package hashivault registers the "hashivault" certmaker provider, backed
+// by HashiCorp Vault's transit engine via sigstore's signature/kms/hashivault
+// implementation.
+package hashivault
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/sigstore/sigstore/pkg/signature"
+	sigkms "github.com/sigstore/sigstore/pkg/signature/kms"
+	// Initialize HashiVault KMS provider
+	_ "github.com/sigstore/sigstore/pkg/signature/kms/hashivault"
+
+	"github.com/ianhundere/fulcio/pkg/certmaker/provider"
+)
+
+func init() {
+	provider.Register("hashivault", &hashivaultProvider{})
+}
+
+type hashivaultProvider struct{}
+
+func (hashivaultProvider) Validate(config provider.Config) error {
+	if config.Options == nil {
+		return fmt.Errorf("options map is required for HashiVault KMS")
+	}
+	if config.Options["address"] == "" {
+		return fmt.Errorf("address is required for HashiVault KMS")
+	}
+	if config.Options["token"] == "" {
+		return fmt.Errorf("token is required for HashiVault KMS")
+	}
+
+	validateKeyID := func(keyID, keyType string) error {
+		if keyID == "" {
+			return nil
+		}
+		parts := strings.Split(keyID, "/")
+		if len(parts) < 3 {
+			return fmt.Errorf("hashivault %s must be in format: transit/keys/keyname", keyType)
+		}
+		if parts[0] != "transit" || parts[1] != "keys" {
+			return fmt.Errorf("hashivault %s must start with 'transit/keys/'", keyType)
+		}
+		if parts[2] == "" {
+			return fmt.Errorf("key name cannot be empty for %s", keyType)
+		}
+		return nil
+	}
+
+	if err := validateKeyID(config.RootKeyID, "RootKeyID"); err != nil {
+		return err
+	}
+	if err := validateKeyID(config.IntermediateKeyID, "IntermediateKeyID"); err != nil {
+		return err
+	}
+	if err := validateKeyID(config.LeafKeyID, "LeafKeyID"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (hashivaultProvider) NewSigner(ctx context.Context, config provider.Config, keyID string) (signature.SignerVerifier, error) {
+	if token := config.Options["token"]; token != "" {
+		os.Setenv("VAULT_TOKEN", token)
+	}
+	if addr := config.Options["address"]; addr != "" {
+		os.Setenv("VAULT_ADDR", addr)
+	}
+
+	sv, err := sigkms.Get(ctx, fmt.Sprintf("hashivault://%s", keyID), crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize HashiVault KMS: %w", err)
+	}
+	return sv, nil
+}
+
+// CreateKey provisions keyID ("transit/keys/<name>") as a new ecdsa-p384
+// transit key, with exporting and plaintext backup both disabled so the
+// private key material never leaves Vault.
+func (hashivaultProvider) CreateKey(ctx context.Context, config provider.Config, keyID string) error {
+	parts := strings.Split(keyID, "/")
+	if len(parts) < 3 || parts[0] != "transit" || parts[1] != "keys" || parts[2] == "" {
+		return fmt.Errorf("hashivault key %s must be in format: transit/keys/keyname", keyID)
+	}
+	keyName := parts[2]
+
+	clientConfig := vaultapi.DefaultConfig()
+	if addr := config.Options["address"]; addr != "" {
+		clientConfig.Address = addr
+	}
+	client, err := vaultapi.NewClient(clientConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create HashiVault client: %w", err)
+	}
+	if token := config.Options["token"]; token != "" {
+		client.SetToken(token)
+	}
+
+	_, err = client.Logical().WriteWithContext(ctx, fmt.Sprintf("transit/keys/%s", keyName), map[string]interface{}{
+		"type":                   "ecdsa-p384",
+		"exportable":             false,
+		"allow_plaintext_backup": false,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create HashiVault transit key %s: %w", keyName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package pkcs11 registers the "pkcs11" certmaker provider, backed by a
+// PKCS#11 module (YubiHSM, SoftHSM, Luna, etc.) via crypto11.
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ThalesIgnite/crypto11"
+	"github.com/sigstore/sigstore/pkg/signature"
+
+	"github.com/ianhundere/fulcio/pkg/certmaker/provider"
+)
+
+func init() {
+	provider.Register("pkcs11", &pkcs11Provider{})
+}
+
+type pkcs11Provider struct{}
+
+func (pkcs11Provider) Validate(config provider.Config) error {
+	if config.Options == nil {
+		return fmt.Errorf("options map is required for PKCS#11")
+	}
+	if config.Options["module"] == "" {
+		return fmt.Errorf("module is required for PKCS#11")
+	}
+	if config.Options["slot-id"] == "" && config.Options["token"] == "" {
+		return fmt.Errorf("either slot-id or token is required for PKCS#11")
+	}
+	if config.Options["pin"] == "" && config.Options["pin-source"] == "" && os.Getenv("FULCIO_PKCS11_PIN") == "" {
+		return fmt.Errorf("one of pin, pin-source, or FULCIO_PKCS11_PIN is required for PKCS#11")
+	}
+	if config.Options["object"] == "" && config.RootKeyID == "" && config.IntermediateKeyID == "" && config.LeafKeyID == "" {
+		return fmt.Errorf("one of Options[\"object\"], RootKeyID, IntermediateKeyID, or LeafKeyID is required to select a PKCS#11 key object")
+	}
+	return nil
+}
+
+// NewSigner finds the key pair labeled keyID (the RootKeyID/IntermediateKeyID/
+// LeafKeyID in effect for this call) in the PKCS#11 token, falling back to
+// Options["object"] when keyID is empty. This is what lets a single PKCS#11
+// token serve distinct root/intermediate/leaf keys instead of signing every
+// certificate in the chain with the same object.
+func (pkcs11Provider) NewSigner(_ context.Context, config provider.Config, keyID string) (signature.SignerVerifier, error) {
+	module := config.Options["module"]
+
+	var slot *int
+	if slotID := config.Options["slot-id"]; slotID != "" {
+		id, err := strconv.Atoi(slotID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slot-id %q: %w", slotID, err)
+		}
+		slot = &id
+	}
+
+	pin, err := resolvePin(config.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       module,
+		TokenLabel: config.Options["token"],
+		SlotNumber: slot,
+		Pin:        pin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PKCS#11 session: %w", err)
+	}
+
+	objectLabel := keyID
+	if objectLabel == "" {
+		objectLabel = config.Options["object"]
+	}
+	keyPair, err := ctx.FindKeyPair(nil, []byte(objectLabel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find PKCS#11 key pair for object %q: %w", objectLabel, err)
+	}
+	if keyPair == nil {
+		return nil, fmt.Errorf("no PKCS#11 key pair found for object %q", objectLabel)
+	}
+
+	sv, err := signature.LoadSignerVerifier(keyPair, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap PKCS#11 key pair: %w", err)
+	}
+
+	return sv, nil
+}
+
+// resolvePin resolves the HSM login PIN from Options["pin"],
+// Options["pin-source"] (a file path), or the FULCIO_PKCS11_PIN
+// environment variable, in that order.
+func resolvePin(opts map[string]string) (string, error) {
+	if pin := opts["pin"]; pin != "" {
+		return pin, nil
+	}
+	if source := opts["pin-source"]; source != "" {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return "", fmt.Errorf("failed to read pin-source %q: %w", source, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if pin := os.Getenv("FULCIO_PKCS11_PIN"); pin != "" {
+		return pin, nil
+	}
+	return "", fmt.Errorf("PKCS#11 pin is required: set Options[\"pin\"], Options[\"pin-source\"], or FULCIO_PKCS11_PIN")
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package softkms registers the "softkms" (and "file") certmaker provider,
+// which loads a PEM-encoded private key from disk. It mirrors the softkms
+// provider in the smallstep/crypto KMS ecosystem and supports offline
+// root-of-trust ceremonies on an air-gapped machine.
+package softkms
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"go.step.sm/crypto/pemutil"
+
+	"github.com/ianhundere/fulcio/pkg/certmaker/provider"
+)
+
+func init() {
+	provider.Register("softkms", &softkmsProvider{})
+	provider.Register("file", &softkmsProvider{})
+}
+
+type softkmsProvider struct{}
+
+func (softkmsProvider) Validate(config provider.Config) error {
+	checkKeyFile := func(keyPath, keyType string) error {
+		if keyPath == "" {
+			return nil
+		}
+		data, err := os.ReadFile(keyPath)
+		if err != nil {
+			return fmt.Errorf("softkms %s file not found at %s: %w", keyType, keyPath, err)
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return fmt.Errorf("softkms %s file %s does not contain PEM data", keyType, keyPath)
+		}
+		if isEncryptedPEMBlock(block) && config.Options["password"] == "" && os.Getenv("FULCIO_SOFTKMS_PASSWORD") == "" {
+			return fmt.Errorf("softkms %s file %s is encrypted: provide Options[\"password\"] or FULCIO_SOFTKMS_PASSWORD", keyType, keyPath)
+		}
+		return nil
+	}
+
+	if err := checkKeyFile(config.RootKeyID, "RootKeyID"); err != nil {
+		return err
+	}
+	if err := checkKeyFile(config.IntermediateKeyID, "IntermediateKeyID"); err != nil {
+		return err
+	}
+	if err := checkKeyFile(config.LeafKeyID, "LeafKeyID"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (softkmsProvider) NewSigner(_ context.Context, config provider.Config, keyID string) (signature.SignerVerifier, error) {
+	var opts []pemutil.Options
+	if password, ok := resolvePassword(config.Options); ok {
+		opts = append(opts, pemutil.WithPassword([]byte(password)))
+	}
+
+	key, err := pemutil.Read(keyID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SoftKMS key %s: %w", keyID, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("SoftKMS key %s is not a private signing key", keyID)
+	}
+
+	sv, err := signature.LoadSignerVerifier(signer, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap SoftKMS key: %w", err)
+	}
+
+	return sv, nil
+}
+
+// resolvePassword resolves the passphrase for an encrypted SoftKMS key from
+// Options["password"] or FULCIO_SOFTKMS_PASSWORD, in that order.
+func resolvePassword(opts map[string]string) (string, bool) {
+	if password := opts["password"]; password != "" {
+		return password, true
+	}
+	if password := os.Getenv("FULCIO_SOFTKMS_PASSWORD"); password != "" {
+		return password, true
+	}
+	return "", false
+}
+
+// isEncryptedPEMBlock reports whether a PEM block holds an encrypted
+// private key, covering both PKCS#8 "ENCRYPTED PRIVATE KEY" blocks and the
+// legacy DEK-Info header style.
+func isEncryptedPEMBlock(block *pem.Block) bool {
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		return true
+	}
+	return x509.IsEncryptedPEMBlock(block) //nolint:staticcheck // supporting legacy encrypted PEM headers
+}